@@ -0,0 +1,242 @@
+package arp
+
+import (
+	"net"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket/layers"
+
+	"arplogger/internal/neighbor"
+)
+
+// historySize bounds how many recent samples are kept per IP/MAC so the
+// detector's memory usage stays flat regardless of how long it runs.
+const historySize = 32
+
+// ReasonCode identifies why a Detector raised an Alert.
+type ReasonCode string
+
+const (
+	// ReasonFlapping fires when an IP is seen with too many distinct MACs
+	// within the configured window.
+	ReasonFlapping ReasonCode = "flapping"
+	// ReasonGratuitousARP fires on a gratuitous ARP announcement (SPA == TPA).
+	ReasonGratuitousARP ReasonCode = "gratuitous_arp"
+	// ReasonUnsolicitedReply fires on an ARP reply with no matching request
+	// observed within the window.
+	ReasonUnsolicitedReply ReasonCode = "unsolicited_reply"
+	// ReasonARPScan fires when a single MAC claims too many distinct IPs
+	// within the configured window.
+	ReasonARPScan ReasonCode = "arp_scan"
+	// ReasonUnsolicitedAdvertisement fires on an ICMPv6 Neighbor
+	// Advertisement sent without the Solicited flag set, the NDP equivalent
+	// of a gratuitous ARP announcement.
+	ReasonUnsolicitedAdvertisement ReasonCode = "unsolicited_advertisement"
+)
+
+// Alert describes one piece of suspicious ARP activity detected by a Detector.
+type Alert struct {
+	Reason  ReasonCode
+	IP      netip.Addr
+	MACs    []net.HardwareAddr
+	Packets int
+	Time    time.Time
+}
+
+type sample struct {
+	t   time.Time
+	mac string
+}
+
+// Detector flags ARP and NDP activity that looks like spoofing, gratuitous
+// or unsolicited announcements, or an address scan. It keeps a bounded
+// amount of per-address and per-MAC history so it can run indefinitely.
+type Detector struct {
+	threshold int
+	window    time.Duration
+
+	mu      sync.Mutex
+	ipHist  map[netip.Addr][]sample // recent (time, mac) samples per IP, for flapping
+	macHist map[string][]sample     // recent (time, ip) samples per MAC, for scan detection
+	pending map[[8]byte]time.Time   // recent requests, keyed by requester|target IP, for unsolicited replies
+}
+
+// NewDetector returns a Detector that alerts when an IP is seen with
+// threshold-or-more distinct MACs, or a MAC claims threshold-or-more distinct
+// IPs, within window.
+func NewDetector(threshold int, window time.Duration) *Detector {
+	return &Detector{
+		threshold: threshold,
+		window:    window,
+		ipHist:    make(map[netip.Addr][]sample),
+		macHist:   make(map[string][]sample),
+		pending:   make(map[[8]byte]time.Time),
+	}
+}
+
+// Inspect feeds one ARP packet to the detector and returns any alerts it
+// triggers. layer is assumed to already have valid 6-byte MAC and 4-byte
+// IPv4 address sizes.
+func (d *Detector) Inspect(layer *layers.ARP) []Alert {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	srcIP := netip.AddrFrom4([4]byte(layer.SourceProtAddress))
+	srcMAC := net.HardwareAddr(layer.SourceHwAddress)
+	var alerts []Alert
+
+	gratuitous := slices.Equal(layer.SourceProtAddress, layer.DstProtAddress)
+	if gratuitous {
+		alerts = append(alerts, Alert{Reason: ReasonGratuitousARP, IP: srcIP, MACs: []net.HardwareAddr{srcMAC}, Time: now})
+	}
+
+	switch layer.Operation {
+	case layers.ARPRequest:
+		key := requestKey(layer.SourceProtAddress, layer.DstProtAddress)
+		d.pending[key] = now
+	case layers.ARPReply:
+		if !gratuitous {
+			key := requestKey(layer.DstProtAddress, layer.SourceProtAddress)
+			t, ok := d.pending[key]
+			if !ok || now.Sub(t) > d.window {
+				alerts = append(alerts, Alert{Reason: ReasonUnsolicitedReply, IP: srcIP, MACs: []net.HardwareAddr{srcMAC}, Time: now})
+			}
+		}
+	}
+	d.prunePending(now)
+
+	alerts = append(alerts, d.inspectBinding(srcIP, srcMAC, now)...)
+	return alerts
+}
+
+// InspectNeighborSolicitation feeds one ICMPv6 Neighbor Solicitation binding
+// to the detector and returns any flapping or scan alerts it triggers. NS
+// messages aren't checked for gratuitous or unsolicited-reply activity, since
+// those concepts are specific to ARP's request/reply semantics.
+func (d *Detector) InspectNeighborSolicitation(b neighbor.Binding) []Alert {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.inspectBinding(b.Addr, b.MAC, time.Now())
+}
+
+// InspectNeighborAdvertisement feeds one ICMPv6 Neighbor Advertisement
+// binding to the detector and returns any alerts it triggers: an unsolicited
+// advertisement is the NDP equivalent of a gratuitous ARP announcement, and
+// the binding is also checked for flapping and scan activity.
+func (d *Detector) InspectNeighborAdvertisement(b neighbor.Binding, solicited bool) []Alert {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	var alerts []Alert
+	if !solicited {
+		alerts = append(alerts, Alert{Reason: ReasonUnsolicitedAdvertisement, IP: b.Addr, MACs: []net.HardwareAddr{b.MAC}, Time: now})
+	}
+	alerts = append(alerts, d.inspectBinding(b.Addr, b.MAC, now)...)
+	return alerts
+}
+
+// inspectBinding checks addr/mac for flapping and scan activity, pruning
+// stale history first. It is shared by the ARP and NDP entry points. Caller
+// must hold d.mu.
+func (d *Detector) inspectBinding(addr netip.Addr, mac net.HardwareAddr, now time.Time) []Alert {
+	d.pruneHistory(now)
+
+	var alerts []Alert
+	if macs := d.recordAndCheckFlap(addr, mac, now); macs != nil {
+		alerts = append(alerts, Alert{Reason: ReasonFlapping, IP: addr, MACs: macs, Packets: len(macs), Time: now})
+	}
+	if ips := d.recordAndCheckScan(mac, addr, now); ips > 0 {
+		alerts = append(alerts, Alert{Reason: ReasonARPScan, IP: addr, MACs: []net.HardwareAddr{mac}, Packets: ips, Time: now})
+	}
+	return alerts
+}
+
+// recordAndCheckFlap appends a sample to the IP's history and, once the
+// number of distinct MACs within window reaches threshold, returns them.
+func (d *Detector) recordAndCheckFlap(ip netip.Addr, mac net.HardwareAddr, now time.Time) []net.HardwareAddr {
+	hist := append(d.ipHist[ip], sample{t: now, mac: mac.String()})
+	if len(hist) > historySize {
+		hist = hist[len(hist)-historySize:]
+	}
+	d.ipHist[ip] = hist
+
+	seen := make(map[string]struct{})
+	for _, s := range hist {
+		if now.Sub(s.t) > d.window {
+			continue
+		}
+		seen[s.mac] = struct{}{}
+	}
+	if len(seen) < d.threshold {
+		return nil
+	}
+	macs := make([]net.HardwareAddr, 0, len(seen))
+	for m := range seen {
+		hw, err := net.ParseMAC(m)
+		if err == nil {
+			macs = append(macs, hw)
+		}
+	}
+	return macs
+}
+
+// recordAndCheckScan appends a sample to the MAC's history and, once the
+// number of distinct IPs within window reaches threshold, returns that count.
+func (d *Detector) recordAndCheckScan(mac net.HardwareAddr, ip netip.Addr, now time.Time) int {
+	key := mac.String()
+	hist := append(d.macHist[key], sample{t: now, mac: ip.String()})
+	if len(hist) > historySize {
+		hist = hist[len(hist)-historySize:]
+	}
+	d.macHist[key] = hist
+
+	seen := make(map[string]struct{})
+	for _, s := range hist {
+		if now.Sub(s.t) > d.window {
+			continue
+		}
+		seen[s.mac] = struct{}{}
+	}
+	if len(seen) < d.threshold {
+		return 0
+	}
+	return len(seen)
+}
+
+// prunePending drops pending requests older than window so the map doesn't
+// grow unbounded. Caller must hold d.mu.
+func (d *Detector) prunePending(now time.Time) {
+	for k, t := range d.pending {
+		if now.Sub(t) > d.window {
+			delete(d.pending, k)
+		}
+	}
+}
+
+// pruneHistory drops ipHist/macHist entries whose most recent sample has
+// aged out of window, so a scan or flap touching many distinct keys doesn't
+// grow these maps for the life of the process. Caller must hold d.mu.
+func (d *Detector) pruneHistory(now time.Time) {
+	for k, hist := range d.ipHist {
+		if len(hist) == 0 || now.Sub(hist[len(hist)-1].t) > d.window {
+			delete(d.ipHist, k)
+		}
+	}
+	for k, hist := range d.macHist {
+		if len(hist) == 0 || now.Sub(hist[len(hist)-1].t) > d.window {
+			delete(d.macHist, k)
+		}
+	}
+}
+
+func requestKey(requester, target []byte) [8]byte {
+	var k [8]byte
+	copy(k[0:4], requester)
+	copy(k[4:8], target)
+	return k
+}
@@ -0,0 +1,124 @@
+package arp
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket/layers"
+
+	"arplogger/internal/neighbor"
+)
+
+func arpPacket(op uint16, srcIP, dstIP string, srcMAC, dstMAC string) *layers.ARP {
+	sMAC, _ := net.ParseMAC(srcMAC)
+	dMAC, _ := net.ParseMAC(dstMAC)
+	return &layers.ARP{
+		HwAddressSize:     6,
+		ProtAddressSize:   4,
+		Operation:         op,
+		SourceHwAddress:   sMAC,
+		SourceProtAddress: netip.MustParseAddr(srcIP).AsSlice(),
+		DstHwAddress:      dMAC,
+		DstProtAddress:    netip.MustParseAddr(dstIP).AsSlice(),
+	}
+}
+
+func hasReason(alerts []Alert, reason ReasonCode) bool {
+	for _, a := range alerts {
+		if a.Reason == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func TestInspectGratuitousARPOnRequestAndReply(t *testing.T) {
+	for _, op := range []uint16{layers.ARPRequest, layers.ARPReply} {
+		d := NewDetector(100, time.Second)
+		alerts := d.Inspect(arpPacket(op, "10.0.0.1", "10.0.0.1", "aa:aa:aa:aa:aa:aa", "ff:ff:ff:ff:ff:ff"))
+		if !hasReason(alerts, ReasonGratuitousARP) {
+			t.Errorf("op=%d: expected ReasonGratuitousARP when SPA == TPA, got %+v", op, alerts)
+		}
+		if hasReason(alerts, ReasonUnsolicitedReply) {
+			t.Errorf("op=%d: gratuitous reply should not also be flagged as unsolicited_reply, got %+v", op, alerts)
+		}
+	}
+}
+
+func TestInspectUnsolicitedReply(t *testing.T) {
+	d := NewDetector(100, time.Second)
+	alerts := d.Inspect(arpPacket(layers.ARPReply, "10.0.0.1", "10.0.0.2", "aa:aa:aa:aa:aa:aa", "bb:bb:bb:bb:bb:bb"))
+	if !hasReason(alerts, ReasonUnsolicitedReply) {
+		t.Errorf("expected ReasonUnsolicitedReply for a reply with no matching request, got %+v", alerts)
+	}
+}
+
+func TestInspectFlapping(t *testing.T) {
+	d := NewDetector(3, time.Minute)
+	var alerts []Alert
+	for i, mac := range []string{"aa:aa:aa:aa:aa:01", "aa:aa:aa:aa:aa:02", "aa:aa:aa:aa:aa:03"} {
+		alerts = d.Inspect(arpPacket(layers.ARPRequest, "10.0.0.1", "10.0.0.1", mac, "ff:ff:ff:ff:ff:ff"))
+		if i < 2 && hasReason(alerts, ReasonFlapping) {
+			t.Fatalf("flapping alert fired too early, after %d distinct MACs", i+1)
+		}
+	}
+	if !hasReason(alerts, ReasonFlapping) {
+		t.Errorf("expected ReasonFlapping after threshold distinct MACs for one IP, got %+v", alerts)
+	}
+}
+
+func TestInspectScan(t *testing.T) {
+	d := NewDetector(3, time.Minute)
+	var alerts []Alert
+	for i, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		alerts = d.Inspect(arpPacket(layers.ARPRequest, ip, ip, "aa:aa:aa:aa:aa:aa", "ff:ff:ff:ff:ff:ff"))
+		if i < 2 && hasReason(alerts, ReasonARPScan) {
+			t.Fatalf("scan alert fired too early, after %d distinct IPs", i+1)
+		}
+	}
+	if !hasReason(alerts, ReasonARPScan) {
+		t.Errorf("expected ReasonARPScan after threshold distinct IPs for one MAC, got %+v", alerts)
+	}
+}
+
+func TestHistoryEvictsStaleKeys(t *testing.T) {
+	d := NewDetector(1000, time.Millisecond)
+	d.Inspect(arpPacket(layers.ARPRequest, "10.0.0.1", "10.0.0.1", "aa:aa:aa:aa:aa:01", "ff:ff:ff:ff:ff:ff"))
+	d.Inspect(arpPacket(layers.ARPRequest, "10.0.0.2", "10.0.0.2", "aa:aa:aa:aa:aa:02", "ff:ff:ff:ff:ff:ff"))
+
+	time.Sleep(5 * time.Millisecond)
+	d.Inspect(arpPacket(layers.ARPRequest, "10.0.0.3", "10.0.0.3", "aa:aa:aa:aa:aa:03", "ff:ff:ff:ff:ff:ff"))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.ipHist) != 1 {
+		t.Errorf("ipHist has %d entries after the window elapsed, want 1 (stale IPs should be evicted)", len(d.ipHist))
+	}
+	if len(d.macHist) != 1 {
+		t.Errorf("macHist has %d entries after the window elapsed, want 1 (stale MACs should be evicted)", len(d.macHist))
+	}
+}
+
+func TestInspectNeighborAdvertisementUnsolicited(t *testing.T) {
+	d := NewDetector(100, time.Second)
+	addr := netip.MustParseAddr("fe80::1")
+	mac, _ := net.ParseMAC("aa:aa:aa:aa:aa:aa")
+	alerts := d.InspectNeighborAdvertisement(neighbor.Binding{Addr: addr, MAC: mac}, false)
+	if !hasReason(alerts, ReasonUnsolicitedAdvertisement) {
+		t.Errorf("expected ReasonUnsolicitedAdvertisement for an unsolicited NA, got %+v", alerts)
+	}
+}
+
+func TestInspectNeighborBindingsShareFlapDetection(t *testing.T) {
+	d := NewDetector(2, time.Minute)
+	addr := netip.MustParseAddr("fe80::1")
+	mac1, _ := net.ParseMAC("aa:aa:aa:aa:aa:01")
+	mac2, _ := net.ParseMAC("aa:aa:aa:aa:aa:02")
+	d.InspectNeighborSolicitation(neighbor.Binding{Addr: addr, MAC: mac1})
+	alerts := d.InspectNeighborAdvertisement(neighbor.Binding{Addr: addr, MAC: mac2}, true)
+	if !hasReason(alerts, ReasonFlapping) {
+		t.Errorf("expected ReasonFlapping once an NDP address is seen with two distinct MACs, got %+v", alerts)
+	}
+}
@@ -0,0 +1,59 @@
+package arp
+
+import (
+	"errors"
+
+	"github.com/google/gopacket/pcap"
+)
+
+// interfaceFlagLoopback is libpcap's PCAP_IF_LOOPBACK bit, set in
+// pcap.Interface.Flags for loopback devices.
+const interfaceFlagLoopback = 0x00000001
+
+// InterfaceInfo describes one capture-able network interface, as reported by
+// libpcap, for display via -list-interfaces.
+type InterfaceInfo struct {
+	Name        string
+	Description string
+	IPs         []string
+}
+
+// ListInterfaces returns every network interface libpcap can capture on,
+// along with its assigned IP addresses.
+func ListInterfaces() ([]InterfaceInfo, error) {
+	devices, err := pcap.FindAllDevs()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]InterfaceInfo, 0, len(devices))
+	for _, d := range devices {
+		info := InterfaceInfo{Name: d.Name, Description: d.Description}
+		for _, a := range d.Addresses {
+			if a.IP != nil {
+				info.IPs = append(info.IPs, a.IP.String())
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// SelectInterface picks the first non-loopback network interface that has an
+// assigned IPv4 address, for use when -interface is omitted or set to "auto".
+func SelectInterface() (string, error) {
+	devices, err := pcap.FindAllDevs()
+	if err != nil {
+		return "", err
+	}
+	for _, d := range devices {
+		if d.Flags&interfaceFlagLoopback == interfaceFlagLoopback {
+			continue
+		}
+		for _, a := range d.Addresses {
+			if a.IP.To4() != nil {
+				return d.Name, nil
+			}
+		}
+	}
+	return "", errors.New("no suitable network interface found, use -interface to specify one")
+}
@@ -0,0 +1,166 @@
+package arp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Format selects how log events are rendered.
+type Format int
+
+const (
+	// FormatText renders events as the classic human-readable log lines.
+	FormatText Format = iota
+	// FormatJSON renders one NDJSON object per event.
+	FormatJSON
+)
+
+// Level is the minimum severity a log event must have to be emitted.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// ParseLevel parses a -level flag value ("debug", "info", "warn", "error")
+// into a Level.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return 0, fmt.Errorf("unknown log level: %q", s)
+	}
+}
+
+// logEvent carries everything needed to render one log line, in either
+// format. text is only used by FormatText and never marshaled to JSON.
+type logEvent struct {
+	Time    time.Time `json:"ts"`
+	Event   string    `json:"event"` // "new", "change", "packet", "alert" or "warning"
+	SrcIP   string    `json:"src_ip,omitempty"`
+	SrcMAC  string    `json:"src_mac,omitempty"`
+	DstIP   string    `json:"dst_ip,omitempty"`
+	DstMAC  string    `json:"dst_mac,omitempty"`
+	Op      string    `json:"op,omitempty"`
+	PrevMAC string    `json:"prev_mac,omitempty"`
+	text    string
+}
+
+// leveledLogger is the sink Logger writes events to. It has one
+// implementation per Format.
+type leveledLogger interface {
+	Debug(logEvent)
+	Info(logEvent)
+	Warn(logEvent)
+	Error(logEvent)
+	io.Closer
+}
+
+// textLogger renders events as the classic human-readable log lines,
+// gated by level.
+type textLogger struct {
+	*log.Logger
+	level Level
+}
+
+func newTextLogger(w io.Writer, level Level) *textLogger {
+	return &textLogger{Logger: log.New(w, "", log.LstdFlags), level: level}
+}
+
+func (t *textLogger) Debug(e logEvent) {
+	if t.level <= LevelDebug {
+		t.Println(e.text)
+	}
+}
+
+func (t *textLogger) Info(e logEvent) {
+	if t.level <= LevelInfo {
+		t.Println(e.text)
+	}
+}
+
+func (t *textLogger) Warn(e logEvent) {
+	if t.level <= LevelWarn {
+		t.Println(e.text)
+	}
+}
+
+func (t *textLogger) Error(e logEvent) {
+	if t.level <= LevelError {
+		t.Println(e.text)
+	}
+}
+
+func (t *textLogger) Close() error {
+	if logFile, ok := t.Writer().(*os.File); ok {
+		if err := logFile.Sync(); err != nil {
+			return err
+		}
+		return logFile.Close()
+	}
+	return nil
+}
+
+// jsonLogger renders events as NDJSON, gated by level.
+type jsonLogger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level Level
+}
+
+func newJSONLogger(w io.Writer, level Level) *jsonLogger {
+	return &jsonLogger{w: w, level: level}
+}
+
+func (j *jsonLogger) emit(lvl Level, e logEvent) {
+	if lvl < j.level {
+		return
+	}
+	e.Time = time.Now()
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.w.Write(data)
+}
+
+func (j *jsonLogger) Debug(e logEvent) { j.emit(LevelDebug, e) }
+func (j *jsonLogger) Info(e logEvent)  { j.emit(LevelInfo, e) }
+func (j *jsonLogger) Warn(e logEvent)  { j.emit(LevelWarn, e) }
+func (j *jsonLogger) Error(e logEvent) { j.emit(LevelError, e) }
+
+func (j *jsonLogger) Close() error {
+	if logFile, ok := j.w.(*os.File); ok {
+		if err := logFile.Sync(); err != nil {
+			return err
+		}
+		return logFile.Close()
+	}
+	return nil
+}
+
+func newSink(format Format, w io.Writer, level Level) leveledLogger {
+	if format == FormatJSON {
+		return newJSONLogger(w, level)
+	}
+	return newTextLogger(w, level)
+}
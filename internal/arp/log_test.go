@@ -0,0 +1,69 @@
+package arp
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]Level{
+		"debug":   LevelDebug,
+		"DEBUG":   LevelDebug,
+		"info":    LevelInfo,
+		"warn":    LevelWarn,
+		"warning": LevelWarn,
+		"error":   LevelError,
+	}
+	for s, want := range cases {
+		got, err := ParseLevel(s)
+		if err != nil {
+			t.Errorf("ParseLevel(%q) returned error: %s", s, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", s, got, want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel(\"verbose\") should have returned an error")
+	}
+}
+
+func TestJSONLoggerFiltersByLevel(t *testing.T) {
+	var buf bytes.Buffer
+	j := newJSONLogger(&buf, LevelWarn)
+	j.Debug(logEvent{Event: "packet"})
+	j.Info(logEvent{Event: "new"})
+	j.Warn(logEvent{Event: "change"})
+	j.Error(logEvent{Event: "alert"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d NDJSON lines, want 2 (warn and error only): %q", len(lines), buf.String())
+	}
+	var evt logEvent
+	if err := json.Unmarshal([]byte(lines[0]), &evt); err != nil {
+		t.Fatalf("failed to unmarshal NDJSON line: %s", err)
+	}
+	if evt.Event != "change" {
+		t.Errorf("first emitted event = %q, want \"change\"", evt.Event)
+	}
+}
+
+func TestTextLoggerFiltersByLevel(t *testing.T) {
+	var buf bytes.Buffer
+	tl := newTextLogger(&buf, LevelError)
+	tl.Debug(logEvent{text: "debug line"})
+	tl.Warn(logEvent{text: "warn line"})
+	tl.Error(logEvent{text: "error line"})
+
+	out := buf.String()
+	if strings.Contains(out, "debug line") || strings.Contains(out, "warn line") {
+		t.Errorf("expected only the error-level line, got: %q", out)
+	}
+	if !strings.Contains(out, "error line") {
+		t.Errorf("expected the error-level line to be emitted, got: %q", out)
+	}
+}
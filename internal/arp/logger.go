@@ -3,14 +3,17 @@ package arp
 import (
 	"errors"
 	"io"
-	"log"
 	"net"
 	"net/netip"
 	"os"
 	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/gopacket/layers"
+
+	"arplogger/internal/neighbor"
 )
 
 const (
@@ -33,13 +36,66 @@ const (
 const logWarningPrefix = "[WARNING] "
 const logChangeMappingPrefix = "[CHANGE MAPPING] "
 const logNewMappingPrefix = "[NEW MAPPING] "
+const logAlertPrefix = "[ALERT] "
 
-type ipv4 [4]byte
+// record tracks everything the logger remembers about one address <-> MAC
+// mapping, so that a Snapshot can be restored into an equivalent table later.
+type record struct {
+	mac       net.HardwareAddr
+	firstSeen time.Time
+	lastSeen  time.Time
+	changes   int
+}
 
+// Logger tracks IPv4 <-> MAC mappings learned from ARP and IPv6 <-> MAC
+// mappings learned from ICMPv6 Neighbor Discovery in the same table, so
+// dual-stack networks are covered by one tool.
 type Logger struct {
-	logger   *log.Logger
-	mode     int
-	arptable map[ipv4]net.HardwareAddr
+	sink leveledLogger
+	mode int
+
+	mu       sync.Mutex
+	arptable map[netip.Addr]*record
+
+	statePath string
+	stopFlush chan struct{}
+	flushDone chan struct{}
+
+	detector *Detector
+	onAlert  func(Alert)
+	metrics  MetricsSink
+}
+
+// MetricsSink receives counts of logger activity for external observability.
+// It is implemented by internal/metrics, kept as an interface here so this
+// package doesn't depend on a specific metrics backend.
+type MetricsSink interface {
+	ObservePacket(op string)
+	ObserveNewMapping()
+	ObserveChange()
+	ObserveAlert(reason string)
+	SetTableSize(n int)
+}
+
+// EnableDetection attaches d to the logger so every packet passed to Log is
+// also inspected for spoofing, gratuitous ARP and scan activity.
+func (l *Logger) EnableDetection(d *Detector) {
+	l.detector = d
+}
+
+// EnableMetrics attaches m to the logger so every packet, mapping and alert
+// is also recorded to it.
+func (l *Logger) EnableMetrics(m MetricsSink) {
+	l.metrics = m
+	l.mu.Lock()
+	m.SetTableSize(len(l.arptable))
+	l.mu.Unlock()
+}
+
+// OnAlert registers a callback invoked for every Alert the attached Detector
+// raises, in addition to the logger's own [ALERT] log line.
+func (l *Logger) OnAlert(cb func(Alert)) {
+	l.onAlert = cb
 }
 
 // Log extract packet sender IP/MAC addresses and looking into local ARP table to log the following conditions:
@@ -47,75 +103,177 @@ type Logger struct {
 // - The existing mapping of IP <-> MAC has been changed.
 func (l *Logger) Log(layer *layers.ARP) {
 	if layer.HwAddressSize != 6 {
-		l.logger.Printf(logWarningPrefix+"Packet MAC address size is not correct: %d, but should be 6 bytes",
-			layer.HwAddressSize)
+		l.sink.Warn(logEvent{Event: "warning",
+			text: logWarningPrefix + "Packet MAC address size is not correct, but should be 6 bytes"})
 		return
 	}
 	if layer.ProtAddressSize != 4 {
-		l.logger.Printf(logWarningPrefix+"Packet IPv4 address size is not correct: %d, but should be 4 bytes",
-			layer.ProtAddressSize)
+		l.sink.Warn(logEvent{Event: "warning",
+			text: logWarningPrefix + "Packet IPv4 address size is not correct, but should be 4 bytes"})
 		return
 	}
+	srcAddr := netip.AddrFrom4([4]byte(layer.SourceProtAddress))
+	srcMAC := net.HardwareAddr(layer.SourceHwAddress)
+	dstIP := netip.AddrFrom4([4]byte(layer.DstProtAddress)).String()
+	dstMAC := net.HardwareAddr(layer.DstHwAddress).String()
+	op := operationName(layer.Operation)
 	if l.mode&LogAllPackets == LogAllPackets {
-		l.logger.Println(prettyPrint(layer))
+		l.sink.Debug(logEvent{Event: "packet", SrcIP: srcAddr.String(), SrcMAC: srcMAC.String(), DstIP: dstIP, DstMAC: dstMAC, Op: op,
+			text: prettyPrint(layer)})
+	}
+	if l.metrics != nil {
+		l.metrics.ObservePacket(op)
+	}
+	if l.detector != nil {
+		l.reportAlerts(l.detector.Inspect(layer))
+	}
+	l.observe(neighbor.Binding{Addr: srcAddr, MAC: srcMAC})
+}
+
+// reportAlerts logs an [ALERT] line, invokes onAlert and records metrics for
+// each alert raised by the Detector. Shared by the ARP and NDP entry points.
+func (l *Logger) reportAlerts(alerts []Alert) {
+	for _, a := range alerts {
+		l.sink.Error(logEvent{Event: "alert", SrcIP: a.IP.String(), SrcMAC: macsToString(a.MACs), Op: string(a.Reason),
+			text: logAlertPrefix + "reason=" + string(a.Reason) + " ip=" + a.IP.String() +
+				" macs=" + macsToString(a.MACs)})
+		if l.onAlert != nil {
+			l.onAlert(a)
+		}
+		if l.metrics != nil {
+			l.metrics.ObserveAlert(string(a.Reason))
+		}
+	}
+}
+
+// LogNeighborSolicitation records the binding announced by an ICMPv6
+// Neighbor Solicitation: the sender's own address (from the IPv6 header) and
+// MAC (from the Source Link-Layer Address option), if present.
+func (l *Logger) LogNeighborSolicitation(srcIP net.IP, ns *layers.ICMPv6NeighborSolicitation) {
+	if l.metrics != nil {
+		l.metrics.ObservePacket("NS")
+	}
+	mac := linkLayerAddress(ns.Options, layers.ICMPv6OptSourceAddress)
+	if mac == nil || srcIP == nil || srcIP.IsUnspecified() {
+		return
+	}
+	addr, ok := netip.AddrFromSlice(srcIP)
+	if !ok {
+		return
+	}
+	b := neighbor.Binding{Addr: addr.Unmap(), MAC: mac}
+	if l.detector != nil {
+		l.reportAlerts(l.detector.InspectNeighborSolicitation(b))
+	}
+	l.observe(b)
+}
+
+// LogNeighborAdvertisement records the binding announced by an ICMPv6
+// Neighbor Advertisement: the target address and MAC (from the Target
+// Link-Layer Address option), if present.
+func (l *Logger) LogNeighborAdvertisement(na *layers.ICMPv6NeighborAdvertisement) {
+	if l.metrics != nil {
+		l.metrics.ObservePacket("NA")
+	}
+	mac := linkLayerAddress(na.Options, layers.ICMPv6OptTargetAddress)
+	if mac == nil {
+		return
+	}
+	addr, ok := netip.AddrFromSlice(na.TargetAddress)
+	if !ok {
+		return
 	}
-	ipaddr := ipv4(layer.SourceProtAddress)
-	hwaddr := net.HardwareAddr(layer.SourceHwAddress)
-	if addr, ok := l.arptable[ipaddr]; ok {
-		if slices.Equal(hwaddr, addr) {
-			// already in a ARP table, no need to check
+	b := neighbor.Binding{Addr: addr.Unmap(), MAC: mac}
+	if l.detector != nil {
+		l.reportAlerts(l.detector.InspectNeighborAdvertisement(b, na.Solicited()))
+	}
+	l.observe(b)
+}
+
+// observe updates the address table for b, logging a [NEW MAPPING] or
+// [CHANGE MAPPING] line as appropriate. It is shared by ARP and NDP.
+func (l *Logger) observe(b neighbor.Binding) {
+	addr, mac := b.Addr, b.MAC
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if rec, ok := l.arptable[addr]; ok {
+		if slices.Equal(mac, rec.mac) {
+			// already in the table, no need to check
+			rec.lastSeen = now
 			return
 		}
 		for k, v := range l.arptable {
-			if slices.Equal(hwaddr, v) {
+			if slices.Equal(mac, v.mac) {
 				delete(l.arptable, k)
 				break
 			}
 		}
-		naddr := netip.AddrFrom4(ipaddr)
+		prevMAC := rec.mac.String()
 		if l.mode&LogNewPairs == LogNewPairs {
-			l.logger.Printf(logChangeMappingPrefix+"Previous mapping: %s <-> %s, new mapping: %s <-> %s",
-				naddr, l.arptable[ipaddr], naddr, hwaddr)
+			l.sink.Warn(logEvent{Event: "change", SrcIP: addr.String(), SrcMAC: mac.String(), PrevMAC: prevMAC,
+				text: logChangeMappingPrefix + "Previous mapping: " + addr.String() + " <-> " + prevMAC +
+					", new mapping: " + addr.String() + " <-> " + mac.String()})
 		}
-		l.arptable[ipaddr] = hwaddr
-	} else {
-		var found bool
-		for k, v := range l.arptable {
-			found = slices.Equal(hwaddr, v)
-			if found {
-				if l.mode&LogNewPairs == LogNewPairs {
-					l.logger.Printf(logChangeMappingPrefix+"Previous mapping: %s <-> %s, new mapping: %s <-> %s",
-						netip.AddrFrom4(k), hwaddr, netip.AddrFrom4(ipaddr), hwaddr)
-				}
-				delete(l.arptable, k)
-				l.arptable[ipaddr] = hwaddr
-				break
-			}
+		if l.metrics != nil {
+			l.metrics.ObserveChange()
+		}
+		l.arptable[addr] = &record{mac: mac, firstSeen: rec.firstSeen, lastSeen: now, changes: rec.changes + 1}
+		if l.metrics != nil {
+			l.metrics.SetTableSize(len(l.arptable))
 		}
-		if !found {
+		return
+	}
+	var found bool
+	for k, v := range l.arptable {
+		found = slices.Equal(mac, v.mac)
+		if found {
+			prevAddr := k.String()
 			if l.mode&LogNewPairs == LogNewPairs {
-				l.logger.Printf(logNewMappingPrefix+"%s <-> %s", netip.AddrFrom4(ipaddr), hwaddr)
+				l.sink.Warn(logEvent{Event: "change", SrcIP: addr.String(), SrcMAC: mac.String(), PrevMAC: prevAddr,
+					text: logChangeMappingPrefix + "Previous mapping: " + prevAddr + " <-> " + mac.String() +
+						", new mapping: " + addr.String() + " <-> " + mac.String()})
+			}
+			if l.metrics != nil {
+				l.metrics.ObserveChange()
 			}
-			l.arptable[ipaddr] = hwaddr
+			delete(l.arptable, k)
+			l.arptable[addr] = &record{mac: mac, firstSeen: v.firstSeen, lastSeen: now, changes: v.changes + 1}
+			break
 		}
 	}
+	if !found {
+		if l.mode&LogNewPairs == LogNewPairs {
+			l.sink.Info(logEvent{Event: "new", SrcIP: addr.String(), SrcMAC: mac.String(),
+				text: logNewMappingPrefix + addr.String() + " <-> " + mac.String()})
+		}
+		if l.metrics != nil {
+			l.metrics.ObserveNewMapping()
+		}
+		l.arptable[addr] = &record{mac: mac, firstSeen: now, lastSeen: now}
+	}
+	if l.metrics != nil {
+		l.metrics.SetTableSize(len(l.arptable))
+	}
 }
 
 func (l *Logger) Close() error {
-	if logFile, ok := l.logger.Writer().(*os.File); ok {
-		if err := logFile.Sync(); err != nil {
-			return err
-		}
-		if err := logFile.Close(); err != nil {
+	if l.stopFlush != nil {
+		close(l.stopFlush)
+		<-l.flushDone
+	}
+	if l.statePath != "" {
+		if err := l.flush(); err != nil {
 			return err
 		}
 	}
-	return nil
+	return l.sink.Close()
 }
 
-// NewLogger returns new ARP packet logger with specified logging destination (console or file)
-// and logging mode (all packets or only new pairs of IP <-> MAC)
-func NewLogger(dest, mode int, filename string) (*Logger, error) {
+// NewLogger returns new ARP/NDP packet logger with specified logging destination (console or file),
+// logging mode (all packets or only new pairs of address <-> MAC), output format and minimum log level.
+func NewLogger(dest, mode int, format Format, level Level, filename string) (*Logger, error) {
 	if dest == 0 || mode == 0 {
 		return nil, errors.New("dest and mode arguments should be specified")
 	}
@@ -130,21 +288,44 @@ func NewLogger(dest, mode int, filename string) (*Logger, error) {
 	if dest&Print2Console == Print2Console {
 		writers = append(writers, os.Stdout)
 	}
-	logger := log.New(io.MultiWriter(writers...), "", log.LstdFlags)
-	return &Logger{logger: logger, mode: mode,
-		arptable: make(map[ipv4]net.HardwareAddr)}, nil
+	sink := newSink(format, io.MultiWriter(writers...), level)
+	return &Logger{sink: sink, mode: mode,
+		arptable: make(map[netip.Addr]*record)}, nil
 }
 
-func prettyPrint(layer *layers.ARP) string {
-	var sb strings.Builder
-	var op string
-	switch layer.Operation {
+func operationName(op uint16) string {
+	switch op {
 	case layers.ARPRequest:
-		op = "Request"
+		return "Request"
 	case layers.ARPReply:
-		op = "Reply"
+		return "Reply"
+	default:
+		return ""
+	}
+}
+
+// linkLayerAddress returns the MAC address carried by the first ICMPv6
+// option of the given type, or nil if absent.
+func linkLayerAddress(opts layers.ICMPv6Options, want layers.ICMPv6Opt) net.HardwareAddr {
+	for _, o := range opts {
+		if o.Type == want && len(o.Data) >= 6 {
+			return net.HardwareAddr(o.Data[:6])
+		}
 	}
-	sb.WriteString("Operation: " + op)
+	return nil
+}
+
+func macsToString(macs []net.HardwareAddr) string {
+	strs := make([]string, len(macs))
+	for i, m := range macs {
+		strs[i] = m.String()
+	}
+	return strings.Join(strs, ",")
+}
+
+func prettyPrint(layer *layers.ARP) string {
+	var sb strings.Builder
+	sb.WriteString("Operation: " + operationName(layer.Operation))
 	sb.WriteString(", Source MAC: " + net.HardwareAddr(layer.SourceHwAddress).String())
 	sb.WriteString(", Source IP: " + netip.AddrFrom4([4]byte(layer.SourceProtAddress)).String())
 	sb.WriteString(", Destination MAC: " + net.HardwareAddr(layer.DstHwAddress).String())
@@ -0,0 +1,86 @@
+package arp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+)
+
+// rotateInterval controls how often PcapWriter starts a new capture file.
+const rotateInterval = 1 * time.Hour
+
+// PcapWriter archives observed ARP packets to a rotating sequence of pcap
+// files, so operators can keep raw evidence alongside the human log.
+type PcapWriter struct {
+	mu       sync.Mutex
+	basePath string
+	opened   time.Time
+	file     *os.File
+	writer   *pcapgo.Writer
+}
+
+// NewPcapWriter creates a PcapWriter that writes to files derived from
+// basePath, e.g. "capture.pcap" becomes
+// "capture_2006-01-02_15.04.05.999999999.pcap" (fractional seconds with
+// trailing zeros dropped).
+func NewPcapWriter(basePath string) (*PcapWriter, error) {
+	w := &PcapWriter{basePath: basePath}
+	if err := w.rotate(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// rotate closes the current file, if any, and opens a new one. Caller must
+// hold w.mu, except when called from NewPcapWriter.
+func (w *PcapWriter) rotate() error {
+	if w.file != nil {
+		if err := w.file.Close(); err != nil {
+			return err
+		}
+	}
+	const layout = "2006-01-02_15.04.05.999999999"
+	ext := filepath.Ext(w.basePath)
+	name := strings.TrimSuffix(w.basePath, ext) + "_" + time.Now().Format(layout) + ext
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	writer := pcapgo.NewWriter(file)
+	if err := writer.WriteFileHeader(snaplen, layers.LinkTypeEthernet); err != nil {
+		file.Close()
+		return err
+	}
+	w.file = file
+	w.writer = writer
+	w.opened = time.Now()
+	return nil
+}
+
+// WritePacket appends one packet to the current file, rotating first if
+// rotateInterval has elapsed since the file was opened.
+func (w *PcapWriter) WritePacket(ci gopacket.CaptureInfo, data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if time.Since(w.opened) > rotateInterval {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+	return w.writer.WritePacket(ci, data)
+}
+
+func (w *PcapWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Close()
+}
@@ -0,0 +1,53 @@
+package arp
+
+import (
+	"github.com/google/gopacket/pcap"
+)
+
+// snaplen is the maximum number of bytes to capture per packet: 64K.
+const snaplen = 64*1024 - 1
+
+// bpfFilter admits ARP packets and the ICMPv6 Neighbor Discovery messages
+// (type 135 = Neighbor Solicitation, 136 = Neighbor Advertisement) used to
+// learn IPv6 <-> MAC bindings.
+const bpfFilter = "arp or (icmp6 and (ip6[40] == 135 or ip6[40] == 136))"
+
+// PacketSource opens a pcap.Handle to read ARP traffic from, either a live
+// network interface or a previously captured file.
+type PacketSource interface {
+	Open() (*pcap.Handle, error)
+}
+
+// LiveSource captures ARP traffic from a live network interface.
+type LiveSource struct {
+	Interface string
+}
+
+func (s LiveSource) Open() (*pcap.Handle, error) {
+	handle, err := pcap.OpenLive(s.Interface, snaplen, true, pcap.BlockForever)
+	if err != nil {
+		return nil, err
+	}
+	if err := handle.SetBPFFilter(bpfFilter); err != nil {
+		return nil, err
+	}
+	return handle, nil
+}
+
+// OfflineSource replays ARP traffic from a pcap file previously captured with
+// -pcap-out or another tool, enabling reproducible testing of the detection
+// logic against captured traces.
+type OfflineSource struct {
+	File string
+}
+
+func (s OfflineSource) Open() (*pcap.Handle, error) {
+	handle, err := pcap.OpenOffline(s.File)
+	if err != nil {
+		return nil, err
+	}
+	if err := handle.SetBPFFilter(bpfFilter); err != nil {
+		return nil, err
+	}
+	return handle, nil
+}
@@ -0,0 +1,149 @@
+package arp
+
+import (
+	"encoding/json"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// flushInterval controls how often a Logger created with NewLoggerWithState
+// persists its ARP table to statePath in the background.
+const flushInterval = 30 * time.Second
+
+// StateEntry is the JSON-serializable representation of one IP <-> MAC
+// mapping, as produced by Snapshot and consumed by Restore.
+type StateEntry struct {
+	IP        string    `json:"ip"`
+	MAC       string    `json:"mac"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Changes   int       `json:"changes"`
+}
+
+// State is a point-in-time dump of a Logger's ARP table.
+type State struct {
+	Entries []StateEntry `json:"entries"`
+}
+
+// NewLoggerWithState returns a Logger like NewLogger, additionally seeding its
+// ARP table from statePath (if it exists) and periodically flushing the
+// table back to statePath so it survives restarts.
+func NewLoggerWithState(dest, mode int, format Format, level Level, filename, statePath string) (*Logger, error) {
+	l, err := NewLogger(dest, mode, format, level, filename)
+	if err != nil {
+		return nil, err
+	}
+	if statePath == "" {
+		return l, nil
+	}
+	l.statePath = statePath
+	if err := l.Restore(statePath); err != nil && !os.IsNotExist(err) {
+		l.sink.Close()
+		return nil, err
+	}
+	l.stopFlush = make(chan struct{})
+	l.flushDone = make(chan struct{})
+	go l.runFlusher()
+	return l, nil
+}
+
+// Snapshot returns the current ARP table as a State suitable for JSON
+// serialization.
+func (l *Logger) Snapshot() State {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	state := State{Entries: make([]StateEntry, 0, len(l.arptable))}
+	for addr, rec := range l.arptable {
+		state.Entries = append(state.Entries, StateEntry{
+			IP:        addr.String(),
+			MAC:       rec.mac.String(),
+			FirstSeen: rec.firstSeen,
+			LastSeen:  rec.lastSeen,
+			Changes:   rec.changes,
+		})
+	}
+	return state
+}
+
+// Restore loads a State previously written to path and merges it into the
+// current ARP table via Merge.
+func (l *Logger) Restore(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	return l.Merge(state)
+}
+
+// Merge reconciles state into the current ARP table: existing entries win
+// ties on LastSeen, otherwise the more recently seen entry is kept.
+func (l *Logger) Merge(state State) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, e := range state.Entries {
+		addr, err := netip.ParseAddr(e.IP)
+		if err != nil {
+			continue
+		}
+		mac, err := net.ParseMAC(e.MAC)
+		if err != nil {
+			continue
+		}
+		if existing, ok := l.arptable[addr]; ok && !e.LastSeen.After(existing.lastSeen) {
+			continue
+		}
+		l.arptable[addr] = &record{mac: mac, firstSeen: e.FirstSeen, lastSeen: e.LastSeen, changes: e.Changes}
+	}
+	return nil
+}
+
+// flush atomically writes the current Snapshot to l.statePath.
+func (l *Logger) flush() error {
+	data, err := json.Marshal(l.Snapshot())
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(l.statePath)
+	tmp, err := os.CreateTemp(dir, ".arplogger-state-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), l.statePath)
+}
+
+// runFlusher periodically persists the ARP table until stopFlush is closed.
+func (l *Logger) runFlusher() {
+	defer close(l.flushDone)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stopFlush:
+			return
+		case <-ticker.C:
+			if err := l.flush(); err != nil {
+				l.sink.Warn(logEvent{Event: "warning",
+					text: logWarningPrefix + "Failed to flush ARP state to " + l.statePath + ": " + err.Error()})
+			}
+		}
+	}
+}
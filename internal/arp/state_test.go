@@ -0,0 +1,88 @@
+package arp
+
+import (
+	"net"
+	"net/netip"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"arplogger/internal/neighbor"
+)
+
+func newTestLogger(t *testing.T) *Logger {
+	t.Helper()
+	l, err := NewLogger(Print2Log, LogNewPairs, FormatText, LevelDebug, filepath.Join(t.TempDir(), "arplogger.log"))
+	if err != nil {
+		t.Fatalf("NewLogger failed: %s", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func TestSnapshotRestoreRoundTrip(t *testing.T) {
+	l := newTestLogger(t)
+	addr := netip.MustParseAddr("192.168.1.10")
+	mac, _ := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	l.observe(neighbor.Binding{Addr: addr, MAC: mac})
+
+	state := l.Snapshot()
+	if len(state.Entries) != 1 {
+		t.Fatalf("Snapshot returned %d entries, want 1", len(state.Entries))
+	}
+	if state.Entries[0].IP != addr.String() || state.Entries[0].MAC != mac.String() {
+		t.Errorf("Snapshot entry = %+v, want IP=%s MAC=%s", state.Entries[0], addr, mac)
+	}
+
+	restored := newTestLogger(t)
+	if err := restored.Merge(state); err != nil {
+		t.Fatalf("Merge failed: %s", err)
+	}
+	rec, ok := restored.arptable[addr]
+	if !ok {
+		t.Fatalf("Merge did not restore %s into the table", addr)
+	}
+	if rec.mac.String() != mac.String() {
+		t.Errorf("restored MAC = %s, want %s", rec.mac, mac)
+	}
+}
+
+func TestMergeKeepsExistingOnLastSeenTie(t *testing.T) {
+	l := newTestLogger(t)
+	addr := netip.MustParseAddr("192.168.1.10")
+	existingMAC, _ := net.ParseMAC("aa:aa:aa:aa:aa:aa")
+	incomingMAC, _ := net.ParseMAC("bb:bb:bb:bb:bb:bb")
+	tie := time.Now()
+	l.arptable[addr] = &record{mac: existingMAC, firstSeen: tie, lastSeen: tie}
+
+	err := l.Merge(State{Entries: []StateEntry{{
+		IP: addr.String(), MAC: incomingMAC.String(), FirstSeen: tie, LastSeen: tie,
+	}}})
+	if err != nil {
+		t.Fatalf("Merge failed: %s", err)
+	}
+
+	if got := l.arptable[addr].mac.String(); got != existingMAC.String() {
+		t.Errorf("on a LastSeen tie, Merge kept %s, want the existing entry %s", got, existingMAC)
+	}
+}
+
+func TestMergeOverwritesWithNewerEntry(t *testing.T) {
+	l := newTestLogger(t)
+	addr := netip.MustParseAddr("192.168.1.10")
+	oldMAC, _ := net.ParseMAC("aa:aa:aa:aa:aa:aa")
+	newMAC, _ := net.ParseMAC("bb:bb:bb:bb:bb:bb")
+	now := time.Now()
+	l.arptable[addr] = &record{mac: oldMAC, firstSeen: now, lastSeen: now}
+
+	err := l.Merge(State{Entries: []StateEntry{{
+		IP: addr.String(), MAC: newMAC.String(), FirstSeen: now, LastSeen: now.Add(time.Second),
+	}}})
+	if err != nil {
+		t.Fatalf("Merge failed: %s", err)
+	}
+
+	if got := l.arptable[addr].mac.String(); got != newMAC.String() {
+		t.Errorf("on a newer LastSeen, Merge kept %s, want the incoming entry %s", got, newMAC)
+	}
+}
@@ -5,11 +5,23 @@ NAME
      ARPLogger - Log ARP protocol activity.
 
 DESCRIPTION
-     Intercept ARP packets on specified network interface and log all or only new mapping of IP <-> MAC.
+     Intercept ARP packets, and IPv6 Neighbor Discovery (Neighbor Solicitation
+     and Neighbor Advertisement) messages, on a specified network interface
+     and log all or only new mapping of address <-> MAC. IPv4 and IPv6
+     mappings share the same table, so dual-stack hosts and moves are
+     tracked together.
 
      The following command line flags are available:
 
-     -interface   Network interface to listen ARP packets.
+     -interface   Network interface to listen for ARP and IPv6 Neighbor
+                  Discovery packets. If omitted or set to "auto", the first
+                  non-loopback interface with an IPv4 address is selected
+                  automatically.
+
+     -list-interfaces
+                  List available network interfaces, with their description
+                  and assigned IPs, and exit. Useful on Windows where
+                  interface names like \Device\NPF_{GUID} are opaque.
 
      -log         Store all ARP activity to log file.
 
@@ -17,7 +29,39 @@ DESCRIPTION
 
      -all         Dump all ARP packets.
 
-     -new         Dump only new mapping of IP <-> MAC.
+     -new         Dump only new mapping of address <-> MAC.
+
+     -state       Path to a file for persisting the address table across restarts.
+                  When set, the table is seeded from this file on startup and
+                  flushed back to it periodically, so "new mapping" entries
+                  aren't re-emitted after a restart.
+
+     -detect      Detect ARP spoofing, gratuitous/unsolicited announcements
+                  and address scans, over both ARP and IPv6 Neighbor
+                  Discovery, printing an [ALERT] line for each one.
+
+     -format      Log output format: "text" (default) for human-readable
+                  lines, or "json" for one NDJSON object per event with
+                  fields {ts, event, src_ip, src_mac, dst_ip, dst_mac, op,
+                  prev_mac}.
+
+     -level       Minimum log level to emit: "debug" (default), "info",
+                  "warn" or "error". All ARP packet dumps (-all) are logged
+                  at debug level, new/changed mappings at info/warn, and
+                  alerts at error.
+
+     -pcap-in     Replay ARP traffic from a pcap file instead of a live
+                  interface. Mutually exclusive with -interface.
+
+     -pcap-out    Archive observed ARP packets to a rotating pcap file at
+                  this path, alongside the human log.
+
+     -metrics-addr
+                  Address to serve Prometheus metrics on, e.g. ":9090".
+                  Exposes arplogger_packets_total, arplogger_mappings_total,
+                  arplogger_new_mappings_total, arplogger_mapping_changes_total,
+                  arplogger_alerts_total and arplogger_table_size. Disabled
+                  if empty.
 
 EXAMPLES
 
@@ -0,0 +1,96 @@
+// Package metrics exposes arplogger's internal activity as Prometheus
+// metrics. It is kept separate from internal/arp so that internal/arp does
+// not depend on prometheus/client_golang. The dependency is still compiled
+// into any binary that imports this package, including arplogger itself,
+// whether or not -metrics-addr is set.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Collector implements arp.MetricsSink, recording logger activity as
+// Prometheus counters and gauges on a private registry.
+type Collector struct {
+	registry *prometheus.Registry
+
+	packets        *prometheus.CounterVec
+	mappings       prometheus.Counter
+	newMappings    prometheus.Counter
+	mappingChanges prometheus.Counter
+	alerts         *prometheus.CounterVec
+	tableSize      prometheus.Gauge
+}
+
+// NewCollector returns a Collector with all metrics registered on a fresh
+// registry, so enabling metrics never touches prometheus's global default
+// registry.
+func NewCollector() *Collector {
+	c := &Collector{
+		registry: prometheus.NewRegistry(),
+		packets: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "arplogger_packets_total",
+			Help: "Total number of ARP/NDP packets observed, by operation.",
+		}, []string{"op"}),
+		mappings: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "arplogger_mappings_total",
+			Help: "Total number of address <-> MAC mappings observed, new or changed.",
+		}),
+		newMappings: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "arplogger_new_mappings_total",
+			Help: "Total number of new address <-> MAC mappings observed.",
+		}),
+		mappingChanges: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "arplogger_mapping_changes_total",
+			Help: "Total number of address <-> MAC mapping changes. The changed " +
+				"address is not used as a label to keep cardinality bounded; see " +
+				"the [CHANGE MAPPING] log line for the specific address.",
+		}),
+		alerts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "arplogger_alerts_total",
+			Help: "Total number of detector alerts, by reason.",
+		}, []string{"reason"}),
+		tableSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "arplogger_table_size",
+			Help: "Current number of address <-> MAC mappings held in the table.",
+		}),
+	}
+	c.registry.MustRegister(c.packets, c.mappings, c.newMappings, c.mappingChanges, c.alerts, c.tableSize)
+	return c
+}
+
+// ObservePacket records one observed ARP/NDP packet for the given operation.
+func (c *Collector) ObservePacket(op string) {
+	c.packets.WithLabelValues(op).Inc()
+}
+
+// ObserveNewMapping records one newly learned address <-> MAC mapping.
+func (c *Collector) ObserveNewMapping() {
+	c.mappings.Inc()
+	c.newMappings.Inc()
+}
+
+// ObserveChange records one changed address <-> MAC mapping.
+func (c *Collector) ObserveChange() {
+	c.mappings.Inc()
+	c.mappingChanges.Inc()
+}
+
+// ObserveAlert records one detector alert for reason.
+func (c *Collector) ObserveAlert(reason string) {
+	c.alerts.WithLabelValues(reason).Inc()
+}
+
+// SetTableSize sets the current number of entries in the address table.
+func (c *Collector) SetTableSize(n int) {
+	c.tableSize.Set(float64(n))
+}
+
+// Handler returns an http.Handler serving this Collector's metrics in the
+// Prometheus text exposition format.
+func (c *Collector) Handler() http.Handler {
+	return promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{})
+}
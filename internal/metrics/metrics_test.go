@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollectorCounters(t *testing.T) {
+	c := NewCollector()
+	c.ObservePacket("Request")
+	c.ObservePacket("Request")
+	c.ObservePacket("NS")
+	c.ObserveNewMapping()
+	c.ObserveChange()
+	c.ObserveChange()
+	c.ObserveAlert("arp_scan")
+	c.SetTableSize(5)
+
+	if got := testutil.ToFloat64(c.packets.WithLabelValues("Request")); got != 2 {
+		t.Errorf("packets{op=Request} = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(c.packets.WithLabelValues("NS")); got != 1 {
+		t.Errorf("packets{op=NS} = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(c.newMappings); got != 1 {
+		t.Errorf("newMappings = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(c.mappingChanges); got != 2 {
+		t.Errorf("mappingChanges = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(c.mappings); got != 3 {
+		t.Errorf("mappings = %v, want 3 (1 new + 2 changes)", got)
+	}
+	if got := testutil.ToFloat64(c.alerts.WithLabelValues("arp_scan")); got != 1 {
+		t.Errorf("alerts{reason=arp_scan} = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(c.tableSize); got != 5 {
+		t.Errorf("tableSize = %v, want 5", got)
+	}
+}
+
+func TestHandlerServesExpositionFormat(t *testing.T) {
+	c := NewCollector()
+	c.ObservePacket("Request")
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	c.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != 200 {
+		t.Fatalf("Handler returned status %d, want 200", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "arplogger_packets_total") {
+		t.Errorf("response body missing arplogger_packets_total: %q", body)
+	}
+	if strings.Contains(body, "arplogger_mapping_changes_total{ip=") {
+		t.Errorf("arplogger_mapping_changes_total must not be labeled by ip (unbounded cardinality): %q", body)
+	}
+}
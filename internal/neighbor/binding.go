@@ -0,0 +1,20 @@
+// Package neighbor holds address-family-agnostic types shared by IPv4 ARP
+// and IPv6 NDP tracking, so both can be handled by the same logging and
+// detection code.
+package neighbor
+
+import (
+	"net"
+	"net/netip"
+)
+
+// Binding pairs a network-layer address, IPv4 or IPv6, with the link-layer
+// MAC address it currently resolves to.
+type Binding struct {
+	Addr netip.Addr
+	MAC  net.HardwareAddr
+}
+
+func (b Binding) String() string {
+	return b.Addr.String() + " <-> " + b.MAC.String()
+}
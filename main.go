@@ -2,13 +2,16 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/google/gopacket"
@@ -17,6 +20,7 @@ import (
 
 	"arplogger/internal/arp"
 	"arplogger/internal/help"
+	"arplogger/internal/metrics"
 )
 
 var (
@@ -24,7 +28,22 @@ var (
 	flagPrint2Console = flag.Bool("console", false, "Print to console.")
 	flagLogAll        = flag.Bool("all", false, "Log all ARP activity.")
 	flagLogNewPair    = flag.Bool("new", false, "Log only new ARP pairs: IP <-> MAC.")
-	flagIntf          = flag.String("interface", "", "Network interface to listen for ARP packets.")
+	flagIntf          = flag.String("interface", "", "Network interface to listen for ARP packets. "+
+		"If omitted or set to \"auto\", the first non-loopback interface with an IPv4 address is used.")
+	flagState          = flag.String("state", "", "Path to a file for persisting the ARP table across restarts.")
+	flagDetect         = flag.Bool("detect", false, "Detect ARP spoofing, gratuitous ARP and ARP scans.")
+	flagListInterfaces = flag.Bool("list-interfaces", false, "List available network interfaces and exit.")
+	flagFormat         = flag.String("format", "text", "Log output format: \"text\" or \"json\".")
+	flagLevel          = flag.String("level", "debug", "Minimum log level: \"debug\", \"info\", \"warn\" or \"error\".")
+	flagPcapIn         = flag.String("pcap-in", "", "Replay ARP traffic from a pcap file instead of a live interface. "+
+		"Mutually exclusive with -interface.")
+	flagPcapOut     = flag.String("pcap-out", "", "Archive observed ARP packets to a rotating pcap file at this path.")
+	flagMetricsAddr = flag.String("metrics-addr", "", "Address to serve Prometheus metrics on, e.g. \":9090\". Disabled if empty.")
+)
+
+const (
+	detectThreshold = 5
+	detectWindow    = 10 * time.Second
 )
 
 func genFilename() (string, error) {
@@ -48,7 +67,6 @@ func genFilename() (string, error) {
 }
 
 func parseFlags() bool {
-	flag.Parse()
 	if flag.NFlag() == 0 {
 		fmt.Print(help.Usage)
 		return false
@@ -61,14 +79,47 @@ func parseFlags() bool {
 		fmt.Println("No logging mode is defined. Please, specify -all and/or -new")
 		return false
 	}
-	if len(*flagIntf) == 0 {
-		fmt.Println("No network interface is specified. " +
-			"Please, use -interface to specify source interface to read network packets.")
-		return false
-	}
 	return true
 }
 
+// resolveInterface returns the capture interface to use: the one explicitly
+// passed via -interface, or an auto-selected one if it was left empty or set
+// to "auto".
+func resolveInterface(intf string) (string, error) {
+	if intf == "" || intf == "auto" {
+		return arp.SelectInterface()
+	}
+	return intf, nil
+}
+
+// buildSource returns the PacketSource to capture from: an OfflineSource
+// reading a pcap file when -pcap-in is set, otherwise a LiveSource for
+// -interface (auto-selecting one if it was left empty or set to "auto").
+func buildSource() (arp.PacketSource, error) {
+	if *flagPcapIn != "" {
+		if *flagIntf != "" && *flagIntf != "auto" {
+			return nil, errors.New("-pcap-in and -interface are mutually exclusive")
+		}
+		return arp.OfflineSource{File: *flagPcapIn}, nil
+	}
+	intf, err := resolveInterface(*flagIntf)
+	if err != nil {
+		return nil, err
+	}
+	return arp.LiveSource{Interface: intf}, nil
+}
+
+func printInterfaces() error {
+	infos, err := arp.ListInterfaces()
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		fmt.Printf("%s\t%s\t%s\n", info.Name, info.Description, strings.Join(info.IPs, ", "))
+	}
+	return nil
+}
+
 func getMode() int {
 	var mode int
 	if *flagLogAll {
@@ -91,6 +142,17 @@ func getDest() int {
 	return dest
 }
 
+func getFormat() (arp.Format, error) {
+	switch strings.ToLower(*flagFormat) {
+	case "text":
+		return arp.FormatText, nil
+	case "json":
+		return arp.FormatJSON, nil
+	default:
+		return 0, fmt.Errorf("unknown log format: %q", *flagFormat)
+	}
+}
+
 func getLogFilename(dest *int) string {
 	var filename string
 	if *dest&arp.Print2Log == arp.Print2Log {
@@ -103,39 +165,52 @@ func getLogFilename(dest *int) string {
 	return filename
 }
 
-func openInterface(intf string) (*pcap.Handle, error) {
-	// max packet size: 64K
-	const snaplen = 64*1024 - 1
-	handle, err := pcap.OpenLive(intf, snaplen, true, pcap.BlockForever)
-	if err != nil {
-		return nil, err
-	}
-	if err := handle.SetBPFFilter("arp"); err != nil {
-		return nil, err
-	}
-	return handle, nil
-}
-
-func processPackets(ctx context.Context, handle *pcap.Handle, logger *arp.Logger) {
+func processPackets(ctx context.Context, handle *pcap.Handle, logger *arp.Logger, pcapWriter *arp.PcapWriter) {
 	src := gopacket.NewPacketSource(handle, layers.LayerTypeEthernet)
 	in := src.Packets()
 read:
 	for {
-		var packet gopacket.Packet
 		select {
 		case <-ctx.Done():
 			break read
-		case packet = <-in:
-			layer := packet.Layer(layers.LayerTypeARP)
-			if layer == nil {
+		case packet, ok := <-in:
+			if !ok {
+				break read
+			}
+			if packet.Layer(layers.LayerTypeARP) == nil &&
+				packet.Layer(layers.LayerTypeICMPv6NeighborSolicitation) == nil &&
+				packet.Layer(layers.LayerTypeICMPv6NeighborAdvertisement) == nil {
 				continue
 			}
-			logger.Log(layer.(*layers.ARP))
+			if pcapWriter != nil {
+				if err := pcapWriter.WritePacket(packet.Metadata().CaptureInfo, packet.Data()); err != nil {
+					fmt.Printf("Failed to archive packet to pcap file: %s.\n", err)
+				}
+			}
+			switch layer := packet.Layer(layers.LayerTypeARP); {
+			case layer != nil:
+				logger.Log(layer.(*layers.ARP))
+			case packet.Layer(layers.LayerTypeICMPv6NeighborSolicitation) != nil:
+				ns := packet.Layer(layers.LayerTypeICMPv6NeighborSolicitation).(*layers.ICMPv6NeighborSolicitation)
+				if ipLayer := packet.Layer(layers.LayerTypeIPv6); ipLayer != nil {
+					logger.LogNeighborSolicitation(ipLayer.(*layers.IPv6).SrcIP, ns)
+				}
+			case packet.Layer(layers.LayerTypeICMPv6NeighborAdvertisement) != nil:
+				na := packet.Layer(layers.LayerTypeICMPv6NeighborAdvertisement).(*layers.ICMPv6NeighborAdvertisement)
+				logger.LogNeighborAdvertisement(na)
+			}
 		}
 	}
 }
 
 func main() {
+	flag.Parse()
+	if *flagListInterfaces {
+		if err := printInterfaces(); err != nil {
+			fmt.Printf("Failed to list network interfaces due to error: %s.", err)
+		}
+		return
+	}
 	if !parseFlags() {
 		return
 	}
@@ -144,23 +219,64 @@ func main() {
 		dest = getDest()
 	)
 	filename := getLogFilename(&dest)
-	logger, err := arp.NewLogger(dest, mode, filename)
+	format, err := getFormat()
+	if err != nil {
+		fmt.Printf("%s.", err)
+		return
+	}
+	level, err := arp.ParseLevel(*flagLevel)
+	if err != nil {
+		fmt.Printf("%s.", err)
+		return
+	}
+	logger, err := arp.NewLoggerWithState(dest, mode, format, level, filename, *flagState)
 	if err != nil {
 		fmt.Printf("Failed to create logger due to error: %s.", err)
 		return
 	}
 	defer logger.Close()
-	handle, err := openInterface(*flagIntf)
+	if *flagDetect {
+		logger.EnableDetection(arp.NewDetector(detectThreshold, detectWindow))
+	}
+	source, err := buildSource()
 	if err != nil {
-		fmt.Printf("Failed to open network interface due to error: %s.", err)
+		fmt.Printf("Failed to select packet source due to error: %s.", err)
+		return
+	}
+	handle, err := source.Open()
+	if err != nil {
+		fmt.Printf("Failed to open packet source due to error: %s.", err)
 		return
 	}
 	defer handle.Close()
+	var pcapWriter *arp.PcapWriter
+	if *flagPcapOut != "" {
+		if pcapWriter, err = arp.NewPcapWriter(*flagPcapOut); err != nil {
+			fmt.Printf("Failed to open pcap output file due to error: %s.", err)
+			return
+		}
+		defer pcapWriter.Close()
+	}
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer stop()
+	if *flagMetricsAddr != "" {
+		collector := metrics.NewCollector()
+		logger.EnableMetrics(collector)
+		srv := &http.Server{Addr: *flagMetricsAddr, Handler: collector.Handler()}
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fmt.Printf("Metrics server stopped due to error: %s.\n", err)
+			}
+		}()
+		go func() {
+			<-ctx.Done()
+			srv.Close()
+		}()
+		fmt.Println("Serving Prometheus metrics on: ", *flagMetricsAddr)
+	}
 	if dest&arp.Print2Log == arp.Print2Log {
 		fmt.Println("Saving to log file: ", filename)
 	}
 	fmt.Println("Waiting for incoming ARP packets...")
-	processPackets(ctx, handle, logger)
+	processPackets(ctx, handle, logger, pcapWriter)
 }